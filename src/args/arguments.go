@@ -0,0 +1,39 @@
+// Package args contains the argument list, defined as a struct, along with a method that validates passed in args
+package args
+
+import (
+	sdkArgs "github.com/newrelic/infra-integrations-sdk/args"
+)
+
+// ArgumentList struct that holds all Postgresql arguments
+type ArgumentList struct {
+	sdkArgs.DefaultArgumentList
+	Username                 string `default:"" help:"The username for the postgres database"`
+	Password                 string `default:"" help:"The password for the specified username"`
+	Hostname                 string `default:"localhost" help:"The host running the database to monitor"`
+	Port                     string `default:"5432" help:"The port to connect to the host on"`
+	Database                 string `default:"" help:"The name of the database to connect to"`
+	CollectionList           string `default:"{}" help:"A JSON object of the collection list to use for table and index level metrics"`
+	Timeout                  string `default:"10" help:"Timeout, in seconds, for a single query against the database"`
+	QueryTimeout             string `default:"10" help:"Timeout, in seconds, applied per query via context.WithTimeout. Overrides Timeout when set"`
+	CollectionTimeout        string `default:"55" help:"Overall timeout, in seconds, for a single collection cycle across all Populate* calls"`
+	MaxConcurrentCollections int    `default:"1" help:"Maximum number of databases collected concurrently by the table and index collectors"`
+	EnableSSL                bool   `default:"false" help:"If true will attempt to connect to the database using SSL"`
+	TrustServerCertificate   bool   `default:"false" help:"If true, the server certificate is not verified for SSL. Used when EnableSSL is true"`
+	SSLRootCertLocation      string `default:"" help:"The path to the SSL Certificate Authority, used to verify the server's certificate when EnableSSL and TrustServerCertificate are true"`
+	PgBouncer                bool   `default:"false" help:"Boolean value indicating the instance is a pgbouncer instance, defaults to false"`
+	CollectDbLockMetrics     bool   `default:"false" help:"Boolean value indicating whether to collect lock metrics for each database, off by default due to its expensive nature"`
+	CollectBloatMetrics      bool   `default:"true" help:"Boolean value indicating whether to collect bloat metrics"`
+	CustomMetricsConfig      string `default:"" help:"Path to a YAML file of user-defined custom SQL metric queries to collect alongside the built-in metrics"`
+	Driver                   string `default:"pgx" help:"The SQL driver used by the database and database-lock metric collectors only: 'pgx' or 'pq'. All other collectors (instance, table, index, pgbouncer, query stats) always use pgx regardless of this setting"`
+	CollectQueryStats        bool   `default:"false" help:"Boolean value indicating whether to collect per-statement metrics from pg_stat_statements"`
+	ObfuscateQueries         bool   `default:"true" help:"Boolean value indicating whether to strip literals from pg_stat_statements query text before it is emitted, used only when CollectQueryStats is true"`
+	CollectorMaxFailures     int    `default:"3" help:"Number of consecutive failures a (collector, database) pair tolerates before its circuit breaker trips"`
+	CollectorSkipRuns        int    `default:"5" help:"Number of collection cycles a tripped (collector, database) pair is skipped for before being retried"`
+	CollectorStatePath       string `default:"" help:"Path to a JSON file used to persist per-(collector, database) circuit breaker state across invocations of this integration. Defaults to a path derived from hostname and port under os.TempDir()"`
+}
+
+// Validate validates the arguments
+func (al ArgumentList) Validate() error {
+	return nil
+}
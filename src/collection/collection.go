@@ -0,0 +1,24 @@
+// Package collection holds the data structures describing which databases, schemas and
+// tables the integration should collect table/index level metrics for.
+package collection
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// SchemaList is a map of schema name to the list of tables within it to collect
+type SchemaList map[string][]string
+
+// DatabaseList is a map of database name to the SchemaList to collect for that database
+type DatabaseList map[string]SchemaList
+
+// ParseCollectionList unmarshals raw, the JSON object passed via --collection_list, into a
+// DatabaseList.
+func ParseCollectionList(raw string) (DatabaseList, error) {
+	var databaseList DatabaseList
+	if err := json.Unmarshal([]byte(raw), &databaseList); err != nil {
+		return nil, fmt.Errorf("failed to parse collection_list: %w", err)
+	}
+	return databaseList, nil
+}
@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/newrelic/nri-postgresql/src/connection"
+)
+
+// pgxDatabaseRepository implements DatabaseRepository on top of the pgx-backed
+// connection.PGSQLConnection introduced to share a pool across a collection cycle.
+type pgxDatabaseRepository struct {
+	con *connection.PGSQLConnection
+}
+
+// NewPgxDatabaseRepository builds a DatabaseRepository backed by an already-open
+// connection.PGSQLConnection.
+func NewPgxDatabaseRepository(con *connection.PGSQLConnection) DatabaseRepository {
+	return &pgxDatabaseRepository{con: con}
+}
+
+func (r *pgxDatabaseRepository) CollectDatabaseMetrics(ctx context.Context, dataModels interface{}, query string) error {
+	return r.con.QueryContext(ctx, dataModels, query)
+}
+
+// Close is a no-op: con is a pool shared across the collection cycle and owned by
+// connection.Info, which closes it once the whole cycle is done.
+func (r *pgxDatabaseRepository) Close() error {
+	return nil
+}
@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	// lib/pq registers the "postgres" database/sql driver used by this adapter
+	_ "github.com/lib/pq"
+	"github.com/newrelic/nri-postgresql/src/connection"
+)
+
+// pqDatabaseRepository implements DatabaseRepository on top of database/sql and lib/pq,
+// preserving the driver behavior the collector used before pgx was introduced. Selected via
+// --driver=pq.
+type pqDatabaseRepository struct {
+	db *sql.DB
+}
+
+// NewPqDatabaseRepository opens a lib/pq connection to database and returns a
+// DatabaseRepository backed by it.
+func NewPqDatabaseRepository(ci *connection.Info, database string) (DatabaseRepository, error) {
+	db, err := sql.Open("postgres", ci.DSN(database))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lib/pq connection to database %s: %w", database, err)
+	}
+
+	return &pqDatabaseRepository{db: db}, nil
+}
+
+func (r *pqDatabaseRepository) CollectDatabaseMetrics(ctx context.Context, dataModels interface{}, query string) error {
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("lib/pq database query failed: %w", err)
+	}
+	defer rows.Close()
+
+	return scanRowsIntoModels(rows, dataModels)
+}
+
+// Close closes the database/sql connection this repository opened.
+func (r *pqDatabaseRepository) Close() error {
+	return r.db.Close()
+}
+
+// scanRowsIntoModels copies rows into dataModels, a pointer to a slice of structs tagged with
+// `db:"column_name"` -- the database/sql equivalent of connection.scanRows, needed because
+// lib/pq exposes columns/values through *sql.Rows rather than pgx's row/field-description API.
+func scanRowsIntoModels(rows *sql.Rows, dataModels interface{}) error {
+	columns, err := rows.Columns()
+	if err != nil {
+		return fmt.Errorf("failed to read columns: %w", err)
+	}
+
+	slicePtr := reflect.ValueOf(dataModels)
+	if slicePtr.Kind() != reflect.Ptr || slicePtr.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("dataModels must be a pointer to a slice, got %T", dataModels)
+	}
+
+	sliceVal := slicePtr.Elem()
+	elemType := sliceVal.Type().Elem()
+	structType := elemType
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+
+		if err := rows.Scan(pointers...); err != nil {
+			return fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		newElem := reflect.New(structType).Elem()
+		assignRowToModel(columns, values, newElem)
+
+		if elemType.Kind() == reflect.Ptr {
+			ptr := reflect.New(structType)
+			ptr.Elem().Set(newElem)
+			sliceVal.Set(reflect.Append(sliceVal, ptr))
+		} else {
+			sliceVal.Set(reflect.Append(sliceVal, newElem))
+		}
+	}
+
+	return rows.Err()
+}
+
+// assignRowToModel sets each field of dst (a struct value) whose `db` tag matches a name in
+// columns to the correspondingly-indexed value in values, converting lib/pq's []byte numeric
+// encoding back into a concrete type first. Split out from scanRowsIntoModels so the
+// tag-matching/conversion logic can be unit tested without a real *sql.Rows.
+func assignRowToModel(columns []string, values []interface{}, dst reflect.Value) {
+	structType := dst.Type()
+	for i := 0; i < structType.NumField(); i++ {
+		tag := structType.Field(i).Tag.Get("db")
+		if tag == "" {
+			continue
+		}
+
+		for colIdx, name := range columns {
+			if name != tag || values[colIdx] == nil {
+				continue
+			}
+			assignField(dst.Field(i), convertPqValue(values[colIdx]))
+			break
+		}
+	}
+}
+
+// assignField sets field to value, converting between assignable numeric/string types the way
+// lib/pq and pgx's driver types differ (mirrors connection.assignField).
+func assignField(field reflect.Value, value interface{}) {
+	v := reflect.ValueOf(value)
+	if !v.Type().AssignableTo(field.Type()) {
+		if field.Kind() == reflect.Ptr {
+			ptr := reflect.New(field.Type().Elem())
+			if v.Type().ConvertibleTo(field.Type().Elem()) {
+				ptr.Elem().Set(v.Convert(field.Type().Elem()))
+				field.Set(ptr)
+			}
+			return
+		}
+		if v.Type().ConvertibleTo(field.Type()) {
+			field.Set(v.Convert(field.Type()))
+		}
+		return
+	}
+	field.Set(v)
+}
+
+// convertPqValue converts the []byte lib/pq returns for numeric and other non-string columns
+// when scanned into interface{} back into a concrete int64, float64 or string, mirroring the
+// concrete types pgx's rows.Values() returns natively. Without this, assignField cannot convert
+// a []byte into a numeric model field, so every numeric pq-driver database metric stays zero.
+func convertPqValue(raw interface{}) interface{} {
+	b, ok := raw.([]byte)
+	if !ok {
+		return raw
+	}
+
+	s := string(b)
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	return s
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConvertPqValue(t *testing.T) {
+	tests := []struct {
+		name string
+		in   interface{}
+		want interface{}
+	}{
+		{name: "integer bytes", in: []byte("42"), want: int64(42)},
+		{name: "float bytes", in: []byte("3.14"), want: 3.14},
+		{name: "string bytes", in: []byte("available"), want: "available"},
+		{name: "non-byte passthrough", in: int64(7), want: int64(7)},
+		{name: "nil passthrough", in: nil, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := convertPqValue(tt.in)
+			if got != tt.want {
+				t.Errorf("convertPqValue(%#v) = %#v, want %#v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+type testDatabaseRow struct {
+	DatabaseName string `db:"datname"`
+	Backends     int64  `db:"numbackends"`
+}
+
+func TestAssignRowToModel(t *testing.T) {
+	columns := []string{"datname", "numbackends", "untagged_column"}
+	values := []interface{}{[]byte("postgres"), []byte("3"), "ignored"}
+
+	dst := reflect.New(reflect.TypeOf(testDatabaseRow{})).Elem()
+	assignRowToModel(columns, values, dst)
+
+	row := dst.Interface().(testDatabaseRow)
+	if row.DatabaseName != "postgres" {
+		t.Errorf("expected DatabaseName %q, got %q", "postgres", row.DatabaseName)
+	}
+	if row.Backends != 3 {
+		t.Errorf("expected Backends 3, got %d", row.Backends)
+	}
+}
+
+func TestAssignRowToModel_SkipsNilColumns(t *testing.T) {
+	columns := []string{"datname", "numbackends"}
+	values := []interface{}{[]byte("postgres"), nil}
+
+	dst := reflect.New(reflect.TypeOf(testDatabaseRow{})).Elem()
+	assignRowToModel(columns, values, dst)
+
+	row := dst.Interface().(testDatabaseRow)
+	if row.DatabaseName != "postgres" {
+		t.Errorf("expected DatabaseName %q, got %q", "postgres", row.DatabaseName)
+	}
+	if row.Backends != 0 {
+		t.Errorf("expected Backends to stay zero-valued when the column is nil, got %d", row.Backends)
+	}
+}
@@ -0,0 +1,198 @@
+package collector
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/newrelic/infra-integrations-sdk/log"
+)
+
+// permanentError marks an error as one retrying won't fix -- a SQL syntax error, or a column
+// missing on an older Postgresql version. The breaker disables the (collector, database) pair
+// for the rest of the process the first time it sees one, rather than waiting for MaxFailures
+// consecutive failures the way it does for transient errors.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err to mark it as non-retryable. A nil err returns nil.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err (or anything it wraps) was marked with Permanent.
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+// permanentSQLStateClasses are pg_error "class" codes (the first two characters of SQLSTATE)
+// that indicate the query itself is wrong for this server, not that the connection hiccuped --
+// syntax errors and undefined columns/tables/functions chief among them.
+var permanentSQLStateClasses = []string{"42"}
+
+// ClassifyError wraps err with Permanent when it looks like a *pgconn.PgError in one of
+// permanentSQLStateClasses, so a breaker can disable the offending definition for the process
+// lifetime instead of retrying it every collection cycle. Connection failures, timeouts and
+// anything else are left untouched and treated as transient.
+func ClassifyError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		for _, class := range permanentSQLStateClasses {
+			if strings.HasPrefix(pgErr.Code, class) {
+				return Permanent(err)
+			}
+		}
+	}
+	return err
+}
+
+// Breaker tracks consecutive failures per (collectorName, database) pair and, once a pair
+// crosses its failure threshold, skips it for a fixed number of subsequent collection cycles.
+//
+// This integration is a short-lived process invoked once per collection cycle (typically by
+// cron, via the infrastructure agent), so in-memory state alone would never survive past the
+// run that tripped it. Breaker persists its state to statePath after every run and reloads it
+// on construction, so a pair tripped in one invocation stays tripped across the skipRuns
+// invocations that follow.
+type Breaker struct {
+	mu          sync.Mutex
+	state       map[string]*breakerState
+	maxFailures int
+	skipRuns    int
+	statePath   string
+}
+
+// breakerState's fields are exported so encoding/json can (de)serialize it across invocations.
+type breakerState struct {
+	ConsecutiveFailures int `json:"consecutiveFailures"`
+	SkipRemaining       int `json:"skipRemaining"`
+}
+
+// NewBreaker builds a Breaker that trips after maxFailures consecutive failures and then
+// skips the pair for skipRuns collection cycles. If statePath is non-empty, any state
+// persisted by a prior invocation's Save call is loaded immediately.
+func NewBreaker(maxFailures, skipRuns int, statePath string) *Breaker {
+	if maxFailures <= 0 {
+		maxFailures = 1
+	}
+	b := &Breaker{
+		state:       make(map[string]*breakerState),
+		maxFailures: maxFailures,
+		skipRuns:    skipRuns,
+		statePath:   statePath,
+	}
+	b.load()
+	return b
+}
+
+// load populates state from statePath, leaving state empty (as if this were the first ever
+// invocation) if the file doesn't exist yet or can't be parsed.
+func (b *Breaker) load() {
+	if b.statePath == "" {
+		return
+	}
+
+	data, err := os.ReadFile(b.statePath)
+	if err != nil {
+		return
+	}
+
+	var persisted map[string]*breakerState
+	if err := json.Unmarshal(data, &persisted); err != nil {
+		log.Warn("Discarding unreadable collector circuit breaker state at %s: %s", b.statePath, err.Error())
+		return
+	}
+
+	b.state = persisted
+}
+
+// Save persists the breaker's current state to statePath so it survives to the next
+// invocation of this process. Call once, after a collection cycle completes. A Breaker built
+// with an empty statePath is a no-op.
+func (b *Breaker) Save() error {
+	if b.statePath == "" {
+		return nil
+	}
+
+	// statePath defaults to a predictable name under os.TempDir(), a world-writable
+	// directory, so refuse to write through a pre-existing symlink someone else planted
+	// there rather than silently following it to an arbitrary file.
+	if info, err := os.Lstat(b.statePath); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		return fmt.Errorf("refusing to write collector circuit breaker state: %s is a symlink", b.statePath)
+	}
+
+	b.mu.Lock()
+	data, err := json.Marshal(b.state)
+	b.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to marshal collector circuit breaker state: %w", err)
+	}
+
+	if err := os.WriteFile(b.statePath, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write collector circuit breaker state to %s: %w", b.statePath, err)
+	}
+	return nil
+}
+
+func breakerKey(collectorName, database string) string {
+	return collectorName + "|" + database
+}
+
+// Allow reports whether collectorName should run against database this cycle, consuming one
+// skip if the pair is currently tripped.
+func (b *Breaker) Allow(collectorName, database string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	st, ok := b.state[breakerKey(collectorName, database)]
+	if !ok || st.SkipRemaining <= 0 {
+		return true
+	}
+
+	st.SkipRemaining--
+	return false
+}
+
+// Record updates the breaker with the outcome of a run. A nil err resets the failure count; a
+// non-nil err increments it, tripping the breaker once the threshold for its kind is reached --
+// a single permanent error trips it immediately, transient errors need maxFailures in a row.
+func (b *Breaker) Record(collectorName, database string, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	key := breakerKey(collectorName, database)
+	st, ok := b.state[key]
+	if !ok {
+		st = &breakerState{}
+		b.state[key] = st
+	}
+
+	if err == nil {
+		st.ConsecutiveFailures = 0
+		st.SkipRemaining = 0
+		return
+	}
+
+	st.ConsecutiveFailures++
+
+	threshold := b.maxFailures
+	if IsPermanent(err) {
+		threshold = 1
+	}
+
+	if st.ConsecutiveFailures >= threshold {
+		st.SkipRemaining = b.skipRuns
+	}
+}
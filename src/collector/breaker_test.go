@@ -0,0 +1,125 @@
+package collector
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestClassifyError_PermanentSQLStateClass(t *testing.T) {
+	err := &pgconn.PgError{Code: "42703"} // undefined_column
+	if !IsPermanent(ClassifyError(err)) {
+		t.Errorf("expected SQLSTATE class 42 to be classified as permanent")
+	}
+}
+
+func TestClassifyError_TransientSQLStateClass(t *testing.T) {
+	err := &pgconn.PgError{Code: "08006"} // connection_failure
+	if IsPermanent(ClassifyError(err)) {
+		t.Errorf("expected SQLSTATE class 08 to be classified as transient")
+	}
+}
+
+func TestClassifyError_NonPgError(t *testing.T) {
+	err := errors.New("context deadline exceeded")
+	if IsPermanent(ClassifyError(err)) {
+		t.Errorf("expected a non-pgconn error to be left untouched as transient")
+	}
+}
+
+func TestBreaker_AllowsUntilThreshold(t *testing.T) {
+	b := NewBreaker(2, 3, "")
+
+	b.Record("table", "db", errors.New("transient"))
+	if !b.Allow("table", "db") {
+		t.Fatalf("expected pair to still be allowed after 1 of 2 failures")
+	}
+
+	b.Record("table", "db", errors.New("transient"))
+	if b.Allow("table", "db") {
+		t.Fatalf("expected pair to be tripped after reaching maxFailures")
+	}
+}
+
+func TestBreaker_PermanentErrorTripsImmediately(t *testing.T) {
+	b := NewBreaker(5, 1, "")
+
+	b.Record("table", "db", Permanent(errors.New("syntax error")))
+	if b.Allow("table", "db") {
+		t.Fatalf("expected a single permanent error to trip the breaker regardless of maxFailures")
+	}
+}
+
+func TestBreaker_SkipCountdownExpires(t *testing.T) {
+	b := NewBreaker(1, 2, "")
+
+	b.Record("table", "db", errors.New("transient"))
+
+	if b.Allow("table", "db") {
+		t.Fatalf("expected pair to be tripped immediately after threshold")
+	}
+	if b.Allow("table", "db") {
+		t.Fatalf("expected pair to still be skipped on the second consumed skip")
+	}
+	if !b.Allow("table", "db") {
+		t.Fatalf("expected pair to be allowed again once skipRuns is exhausted")
+	}
+}
+
+func TestBreaker_SuccessResetsState(t *testing.T) {
+	b := NewBreaker(2, 3, "")
+
+	b.Record("table", "db", errors.New("transient"))
+	b.Record("table", "db", nil)
+	b.Record("table", "db", errors.New("transient"))
+
+	if !b.Allow("table", "db") {
+		t.Fatalf("expected a success to reset the consecutive failure count")
+	}
+}
+
+func TestBreaker_SaveAndLoadRoundTrip(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "breaker-state.json")
+
+	b := NewBreaker(1, 5, statePath)
+	b.Record("table", "db", errors.New("transient"))
+	if err := b.Save(); err != nil {
+		t.Fatalf("Save returned unexpected error: %s", err)
+	}
+
+	reloaded := NewBreaker(1, 5, statePath)
+	if reloaded.Allow("table", "db") {
+		t.Errorf("expected trip recorded before Save to survive into a freshly constructed Breaker")
+	}
+}
+
+func TestBreaker_LoadMissingFileIsNoop(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "does-not-exist.json")
+
+	b := NewBreaker(1, 5, statePath)
+	if !b.Allow("table", "db") {
+		t.Errorf("expected a Breaker with no prior state file to allow by default")
+	}
+}
+
+func TestBreaker_SaveRefusesSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target.json")
+	if err := os.WriteFile(target, []byte("{}"), 0o600); err != nil {
+		t.Fatalf("failed to set up symlink target: %s", err)
+	}
+
+	statePath := filepath.Join(dir, "state.json")
+	if err := os.Symlink(target, statePath); err != nil {
+		t.Fatalf("failed to set up symlink: %s", err)
+	}
+
+	b := NewBreaker(1, 5, statePath)
+	b.Record("table", "db", errors.New("transient"))
+	if err := b.Save(); err == nil {
+		t.Errorf("expected Save to refuse to write through a pre-existing symlink")
+	}
+}
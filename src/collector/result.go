@@ -0,0 +1,23 @@
+// Package collector holds the structured result type every Populate* collector function
+// returns, and a small per-(collector, database) circuit breaker that stops a collection
+// cycle from repeating the same expensive, doomed-to-fail query run after run.
+package collector
+
+import "time"
+
+// Result summarizes the outcome of a single collector's run so a top-level aggregator can log
+// a summary and emit a self-metric instead of each collector logging and swallowing its own
+// failures independently.
+type Result struct {
+	Name        string
+	RowsEmitted int
+	Err         error
+	Duration    time.Duration
+}
+
+// Run times fn and wraps its outcome in a Result named name.
+func Run(name string, fn func() (int, error)) Result {
+	start := time.Now()
+	rows, err := fn()
+	return Result{Name: name, RowsEmitted: rows, Err: err, Duration: time.Since(start)}
+}
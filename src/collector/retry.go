@@ -0,0 +1,49 @@
+package collector
+
+import (
+	"context"
+	"time"
+)
+
+// RetryConfig controls Retry's backoff schedule.
+type RetryConfig struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+}
+
+// DefaultRetryConfig retries a transient failure up to 3 attempts total, doubling the delay
+// between attempts starting at 100ms.
+var DefaultRetryConfig = RetryConfig{MaxAttempts: 3, BaseDelay: 100 * time.Millisecond}
+
+// Retry calls fn, retrying with exponential backoff while it returns a transient error, up to
+// cfg.MaxAttempts total attempts. A permanent error (see IsPermanent) is returned immediately
+// without retrying, since retrying it would only fail the same way again. Retrying also stops
+// early if ctx is cancelled or its deadline elapses while waiting between attempts.
+func Retry(ctx context.Context, cfg RetryConfig, fn func() error) error {
+	maxAttempts := cfg.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 1
+	}
+
+	delay := cfg.BaseDelay
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		err = fn()
+		if err == nil || IsPermanent(err) {
+			return err
+		}
+
+		if attempt == maxAttempts {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+
+	return err
+}
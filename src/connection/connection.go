@@ -0,0 +1,332 @@
+// Package connection handles opening and querying Postgresql connections
+package connection
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/newrelic/nri-postgresql/src/args"
+	"github.com/newrelic/nri-postgresql/src/collector"
+)
+
+const defaultQueryTimeout = 10 * time.Second
+
+// Info is the set of connection parameters needed to reach a Postgresql instance. A single
+// Info is shared across a collection cycle so every Populate* call draws from the same
+// cached pgxpool.Pool(s) instead of opening a fresh TCP session per database.
+type Info struct {
+	Username                 string
+	Password                 string
+	Hostname                 string
+	Port                     string
+	Database                 string
+	EnableSSL                bool
+	TrustServerCertificate   bool
+	SSLRootCertLocation      string
+	QueryTimeout             time.Duration
+	MaxConcurrentCollections int
+	Driver                   string
+	Breaker                  *collector.Breaker
+
+	pools *sync.Map // database name -> *pgxpool.Pool
+}
+
+// DefaultConnectionInfo creates a connection Info from the parsed argument list
+func DefaultConnectionInfo(arg *args.ArgumentList) *Info {
+	queryTimeout := defaultQueryTimeout
+	rawQueryTimeout := arg.QueryTimeout
+	if rawQueryTimeout == "" {
+		rawQueryTimeout = arg.Timeout
+	}
+	if parsed, err := time.ParseDuration(rawQueryTimeout + "s"); err == nil {
+		queryTimeout = parsed
+	}
+
+	statePath := arg.CollectorStatePath
+	if statePath == "" {
+		statePath = filepath.Join(os.TempDir(), fmt.Sprintf("nri-postgresql-breaker-%s-%s.json", arg.Hostname, arg.Port))
+	}
+
+	return &Info{
+		Username:                 arg.Username,
+		Password:                 arg.Password,
+		Hostname:                 arg.Hostname,
+		Port:                     arg.Port,
+		Database:                 arg.Database,
+		EnableSSL:                arg.EnableSSL,
+		TrustServerCertificate:   arg.TrustServerCertificate,
+		SSLRootCertLocation:      arg.SSLRootCertLocation,
+		QueryTimeout:             queryTimeout,
+		MaxConcurrentCollections: arg.MaxConcurrentCollections,
+		Driver:                   arg.Driver,
+		Breaker:                  collector.NewBreaker(arg.CollectorMaxFailures, arg.CollectorSkipRuns, statePath),
+		pools:                    &sync.Map{},
+	}
+}
+
+// DatabaseName returns the database this Info is primarily configured against
+func (ci *Info) DatabaseName() string {
+	return ci.Database
+}
+
+// HostPort returns the host and port this Info is configured against
+func (ci *Info) HostPort() (string, string) {
+	return ci.Hostname, ci.Port
+}
+
+// DSN builds a libpq-style connection string for database, used both to open this Info's own
+// pgx pools and by alternate driver adapters (e.g. repository.NewPqDatabaseRepository) that
+// need to reach the same instance over a different client library.
+func (ci *Info) DSN(database string) string {
+	sslMode := "disable"
+	if ci.EnableSSL {
+		sslMode = "verify-full"
+		if ci.TrustServerCertificate {
+			sslMode = "require"
+		}
+	}
+
+	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+		ci.Hostname, ci.Port, ci.Username, ci.Password, database, sslMode)
+
+	if ci.SSLRootCertLocation != "" {
+		dsn += " sslrootcert=" + ci.SSLRootCertLocation
+	}
+
+	return dsn
+}
+
+// NewPool returns the cached *pgxpool.Pool for database, opening and caching one on first
+// use. Pools live for the lifetime of the process, so repeated Populate* calls against the
+// same database reuse connections rather than paying a fresh handshake each time.
+func (ci *Info) NewPool(ctx context.Context, database string) (*pgxpool.Pool, error) {
+	if database == "" {
+		database = ci.Database
+	}
+
+	if cached, ok := ci.pools.Load(database); ok {
+		return cached.(*pgxpool.Pool), nil
+	}
+
+	cfg, err := pgxpool.ParseConfig(ci.DSN(database))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse connection config for database %s: %w", database, err)
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create connection pool for database %s: %w", database, err)
+	}
+
+	actual, loaded := ci.pools.LoadOrStore(database, pool)
+	if loaded {
+		pool.Close()
+		return actual.(*pgxpool.Pool), nil
+	}
+
+	return pool, nil
+}
+
+// NewConnection acquires a PGSQLConnection backed by the shared pool for database.
+func (ci *Info) NewConnection(ctx context.Context, database string) (*PGSQLConnection, error) {
+	pool, err := ci.NewPool(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PGSQLConnection{pool: pool, queryTimeout: ci.QueryTimeout}, nil
+}
+
+// ClosePools closes every pool opened by this Info. Call once at the end of a collection cycle.
+func (ci *Info) ClosePools() {
+	ci.pools.Range(func(_, value interface{}) bool {
+		value.(*pgxpool.Pool).Close()
+		return true
+	})
+}
+
+// PGSQLConnection wraps a single database's pgxpool.Pool and exposes the query helpers used
+// throughout the metrics package.
+type PGSQLConnection struct {
+	pool         *pgxpool.Pool
+	queryTimeout time.Duration
+}
+
+// QueryContext runs query against the pool, bounding each attempt by the connection's
+// configured per-query timeout, and scans the results into dataModels, which must be a
+// pointer to a slice of structs tagged with `db:"column_name"`. Transient failures (anything
+// ClassifyError doesn't mark Permanent) are retried with backoff per collector.DefaultRetryConfig.
+func (c *PGSQLConnection) QueryContext(ctx context.Context, dataModels interface{}, query string, args ...interface{}) error {
+	timeout := c.queryTimeout
+	if timeout <= 0 {
+		timeout = defaultQueryTimeout
+	}
+
+	return collector.Retry(ctx, collector.DefaultRetryConfig, func() error {
+		queryCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		rows, err := c.pool.Query(queryCtx, query, args...)
+		if err != nil {
+			return collector.ClassifyError(fmt.Errorf("query failed: %w", err))
+		}
+		defer rows.Close()
+
+		if err := scanRows(rows, dataModels); err != nil {
+			return collector.ClassifyError(err)
+		}
+		return nil
+	})
+}
+
+// Query is QueryContext against context.Background(), kept for call sites that do not yet
+// carry a request-scoped context.
+func (c *PGSQLConnection) Query(dataModels interface{}, query string, args ...interface{}) error {
+	return c.QueryContext(context.Background(), dataModels, query, args...)
+}
+
+// QueryRows runs query and returns each row as a column-name-to-value map, for callers whose
+// result shape isn't known at compile time (e.g. user-defined custom metric queries).
+// Transient failures are retried with backoff per collector.DefaultRetryConfig.
+func (c *PGSQLConnection) QueryRows(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
+	timeout := c.queryTimeout
+	if timeout <= 0 {
+		timeout = defaultQueryTimeout
+	}
+
+	var results []map[string]interface{}
+	err := collector.Retry(ctx, collector.DefaultRetryConfig, func() error {
+		results = nil
+
+		queryCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		rows, err := c.pool.Query(queryCtx, query, args...)
+		if err != nil {
+			return collector.ClassifyError(fmt.Errorf("query failed: %w", err))
+		}
+		defer rows.Close()
+
+		fieldDescriptions := rows.FieldDescriptions()
+
+		for rows.Next() {
+			values, err := rows.Values()
+			if err != nil {
+				return fmt.Errorf("failed to read row values: %w", err)
+			}
+
+			row := make(map[string]interface{}, len(values))
+			for i, fd := range fieldDescriptions {
+				row[string(fd.Name)] = values[i]
+			}
+			results = append(results, row)
+		}
+
+		return rows.Err()
+	})
+
+	return results, err
+}
+
+// HaveExtensionInSchema determines whether a given extension is installed in the given schema
+func (c *PGSQLConnection) HaveExtensionInSchema(extensionName, schema string) bool {
+	var rows []struct {
+		Installed int `db:"installed"`
+	}
+
+	query := `SELECT count(*) as installed FROM pg_extension e JOIN pg_namespace n ON n.oid = e.extnamespace WHERE e.extname = $1 AND n.nspname = $2`
+	if err := c.Query(&rows, query, extensionName, schema); err != nil {
+		return false
+	}
+
+	return len(rows) > 0 && rows[0].Installed > 0
+}
+
+// Close releases this connection's reference to the underlying pool. The pool itself is
+// closed once, by Info.ClosePools, at the end of the collection cycle.
+func (c *PGSQLConnection) Close() {}
+
+// scanRows copies the contents of rows into dataModels, a pointer to a slice of structs
+// whose fields are tagged with `db:"column_name"`.
+func scanRows(rows pgx.Rows, dataModels interface{}) error {
+	slicePtr := reflect.ValueOf(dataModels)
+	if slicePtr.Kind() != reflect.Ptr || slicePtr.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("dataModels must be a pointer to a slice, got %T", dataModels)
+	}
+
+	sliceVal := slicePtr.Elem()
+	elemType := sliceVal.Type().Elem()
+
+	fieldDescriptions := rows.FieldDescriptions()
+	columnIndexByField := make(map[string]int, len(fieldDescriptions))
+
+	structType := elemType
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+
+	for i := 0; i < structType.NumField(); i++ {
+		tag := structType.Field(i).Tag.Get("db")
+		if tag == "" {
+			continue
+		}
+		for colIdx, fd := range fieldDescriptions {
+			if string(fd.Name) == tag {
+				columnIndexByField[tag] = colIdx
+			}
+		}
+	}
+
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			return fmt.Errorf("failed to read row values: %w", err)
+		}
+
+		newElem := reflect.New(structType).Elem()
+		for i := 0; i < structType.NumField(); i++ {
+			tag := structType.Field(i).Tag.Get("db")
+			colIdx, ok := columnIndexByField[tag]
+			if !ok || values[colIdx] == nil {
+				continue
+			}
+			assignField(newElem.Field(i), values[colIdx])
+		}
+
+		if elemType.Kind() == reflect.Ptr {
+			ptr := reflect.New(structType)
+			ptr.Elem().Set(newElem)
+			sliceVal.Set(reflect.Append(sliceVal, ptr))
+		} else {
+			sliceVal.Set(reflect.Append(sliceVal, newElem))
+		}
+	}
+
+	return rows.Err()
+}
+
+func assignField(field reflect.Value, value interface{}) {
+	v := reflect.ValueOf(value)
+	if !v.Type().AssignableTo(field.Type()) {
+		if field.Kind() == reflect.Ptr {
+			ptr := reflect.New(field.Type().Elem())
+			if v.Type().ConvertibleTo(field.Type().Elem()) {
+				ptr.Elem().Set(v.Convert(field.Type().Elem()))
+				field.Set(ptr)
+			}
+			return
+		}
+		if v.Type().ConvertibleTo(field.Type()) {
+			field.Set(v.Convert(field.Type()))
+		}
+		return
+	}
+	field.Set(v)
+}
@@ -0,0 +1,227 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/newrelic/infra-integrations-sdk/data/metric"
+	"github.com/newrelic/infra-integrations-sdk/integration"
+	"github.com/newrelic/infra-integrations-sdk/log"
+	"github.com/newrelic/nri-postgresql/src/collection"
+	"github.com/newrelic/nri-postgresql/src/collector"
+	"github.com/newrelic/nri-postgresql/src/connection"
+	"gopkg.in/yaml.v3"
+)
+
+// CustomMetricDefinition describes a single user-defined SQL metric query, loaded from the
+// file passed via --custom_metrics_config. It is evaluated dynamically at runtime instead of
+// being compiled into a QueryDefinition like the built-in generate*Definitions are.
+type CustomMetricDefinition struct {
+	Name       string   `yaml:"name"`
+	Query      string   `yaml:"query"`
+	MetricType string   `yaml:"metric_type"`
+	Entity     string   `yaml:"entity"`
+	MinVersion string   `yaml:"min_version"`
+	MaxVersion string   `yaml:"max_version"`
+	Databases  []string `yaml:"databases"`
+}
+
+// loadCustomMetricsConfig reads and parses the YAML file at path into a list of custom metric
+// definitions.
+func loadCustomMetricsConfig(path string) ([]*CustomMetricDefinition, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read custom metrics config %s: %w", path, err)
+	}
+
+	var definitions []*CustomMetricDefinition
+	if err := yaml.Unmarshal(raw, &definitions); err != nil {
+		return nil, fmt.Errorf("failed to parse custom metrics config %s: %w", path, err)
+	}
+
+	return definitions, nil
+}
+
+// appliesToVersion reports whether the definition is in scope for the running server version.
+func (d *CustomMetricDefinition) appliesToVersion(version *semver.Version) bool {
+	if d.MinVersion != "" {
+		min, err := semver.ParseTolerant(d.MinVersion)
+		if err == nil && version.LT(min) {
+			return false
+		}
+	}
+
+	if d.MaxVersion != "" {
+		max, err := semver.ParseTolerant(d.MaxVersion)
+		if err == nil && version.GT(max) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// sourceType maps the YAML metric_type string onto the SDK's metric.SourceType, defaulting to
+// gauge for anything unrecognized.
+func (d *CustomMetricDefinition) sourceType() metric.SourceType {
+	switch strings.ToLower(d.MetricType) {
+	case "rate":
+		return metric.RATE
+	case "delta":
+		return metric.DELTA
+	case "attribute":
+		return metric.ATTRIBUTE
+	default:
+		return metric.GAUGE
+	}
+}
+
+// PopulateCustomMetrics loads configPath and runs every definition that applies to version,
+// emitting each returned column as a metric of the declared type onto the entity the
+// definition targets (instance, database, table or index). The 'table'/'index' column used to
+// route a row to its entity is not emitted, and string-valued columns are emitted as
+// attributes whatever the declared type.
+func PopulateCustomMetrics(ctx context.Context, configPath string, databases collection.DatabaseList, version *semver.Version, instanceEntity *integration.Entity, pgIntegration *integration.Integration, con *connection.PGSQLConnection, ci *connection.Info) collector.Result {
+	return collector.Run("customMetrics", func() (int, error) {
+		if configPath == "" {
+			return 0, nil
+		}
+
+		definitions, err := loadCustomMetricsConfig(configPath)
+		if err != nil {
+			return 0, err
+		}
+
+		rowsEmitted := 0
+		for _, definition := range definitions {
+			if !definition.appliesToVersion(version) {
+				log.Debug("Skipping custom metric '%s': not applicable to server version %s", definition.Name, version.String())
+				continue
+			}
+
+			targetDatabases := definition.Databases
+			if len(targetDatabases) == 0 {
+				targetDatabases = []string{ci.DatabaseName()}
+			}
+
+			breakerName := "customMetrics:" + definition.Name
+			for _, database := range targetDatabases {
+				if !ci.Breaker.Allow(breakerName, database) {
+					log.Debug("Skipping custom metric '%s' for database %s: circuit breaker open", definition.Name, database)
+					continue
+				}
+
+				rows, err := runCustomMetricDefinition(ctx, definition, database, instanceEntity, pgIntegration, con, ci)
+				ci.Breaker.Record(breakerName, database, err)
+				if err != nil {
+					log.Error("Custom metric '%s': %s", definition.Name, err.Error())
+					continue
+				}
+				rowsEmitted += rows
+			}
+		}
+		return rowsEmitted, nil
+	})
+}
+
+func runCustomMetricDefinition(ctx context.Context, definition *CustomMetricDefinition, database string, instanceEntity *integration.Entity, pgIntegration *integration.Integration, con *connection.PGSQLConnection, ci *connection.Info) (int, error) {
+	queryCon := con
+	if database != "" && database != ci.DatabaseName() {
+		var err error
+		queryCon, err = ci.NewConnection(ctx, database)
+		if err != nil {
+			return 0, fmt.Errorf("failed to connect to database %s: %w", database, err)
+		}
+		defer queryCon.Close()
+	}
+
+	rows, err := queryCon.QueryRows(ctx, definition.Query)
+	if err != nil {
+		return 0, fmt.Errorf("query failed: %w", err)
+	}
+
+	rowsEmitted := 0
+	for _, row := range rows {
+		entity, err := customMetricEntity(definition.Entity, database, row, instanceEntity, pgIntegration, ci)
+		if err != nil {
+			log.Error("Custom metric '%s': %s", definition.Name, err.Error())
+			continue
+		}
+
+		metricSet := entity.NewMetricSet("PostgresqlCustomMetricSample",
+			metric.Attribute{Key: "displayName", Value: entity.Metadata.Name},
+			metric.Attribute{Key: "entityName", Value: entity.Metadata.Namespace + ":" + entity.Metadata.Name},
+		)
+
+		skipColumns := identifierColumns(definition.Entity)
+		for column, value := range row {
+			if skipColumns[column] {
+				continue
+			}
+
+			// A string-valued column the query happens to select can't satisfy a
+			// gauge/rate/delta metric_type, so fall back to ATTRIBUTE for it instead of
+			// logging a SetMetric error every row.
+			sourceType := definition.sourceType()
+			if _, isString := value.(string); isString {
+				sourceType = metric.ATTRIBUTE
+			}
+
+			if err := metricSet.SetMetric(column, value, sourceType); err != nil {
+				log.Error("Custom metric '%s': failed to set metric for column %s: %s", definition.Name, column, err.Error())
+			}
+		}
+		rowsEmitted++
+	}
+	return rowsEmitted, nil
+}
+
+// identifierColumns names the row column(s) customMetricEntity consumes to route a row to its
+// entity for the given scope, so runCustomMetricDefinition can skip re-emitting them as metrics.
+func identifierColumns(entityScope string) map[string]bool {
+	switch strings.ToLower(entityScope) {
+	case "table":
+		return map[string]bool{"table": true}
+	case "index":
+		return map[string]bool{"index": true}
+	default:
+		return nil
+	}
+}
+
+// customMetricEntity resolves the entity a custom metric row should be attached to, based on
+// the definition's declared entity scope and the identifying columns in the row.
+func customMetricEntity(entityScope, database string, row map[string]interface{}, instanceEntity *integration.Entity, pgIntegration *integration.Integration, ci *connection.Info) (*integration.Entity, error) {
+	host, port := ci.HostPort()
+	hostIDAttribute := integration.NewIDAttribute("host", host)
+	portIDAttribute := integration.NewIDAttribute("port", port)
+
+	entityMu.Lock()
+	defer entityMu.Unlock()
+
+	switch strings.ToLower(entityScope) {
+	case "", "instance":
+		return instanceEntity, nil
+	case "database":
+		return pgIntegration.Entity(database, "pg-database", hostIDAttribute, portIDAttribute)
+	case "table":
+		tableName, ok := row["table"].(string)
+		if !ok {
+			return nil, fmt.Errorf("entity scope 'table' requires a 'table' column in the query result")
+		}
+		databaseIDAttribute := integration.NewIDAttribute("pg-database", database)
+		return pgIntegration.Entity(tableName, "pg-table", hostIDAttribute, portIDAttribute, databaseIDAttribute)
+	case "index":
+		indexName, ok := row["index"].(string)
+		if !ok {
+			return nil, fmt.Errorf("entity scope 'index' requires an 'index' column in the query result")
+		}
+		databaseIDAttribute := integration.NewIDAttribute("pg-database", database)
+		return pgIntegration.Entity(indexName, "pg-index", hostIDAttribute, portIDAttribute, databaseIDAttribute)
+	default:
+		return nil, fmt.Errorf("unknown entity scope '%s'", entityScope)
+	}
+}
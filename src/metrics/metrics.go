@@ -1,63 +1,122 @@
 package metrics
 
 import (
+	"context"
+	"fmt"
 	"reflect"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/blang/semver"
 	"github.com/newrelic/infra-integrations-sdk/data/metric"
 	"github.com/newrelic/infra-integrations-sdk/integration"
 	"github.com/newrelic/infra-integrations-sdk/log"
 	"github.com/newrelic/nri-postgresql/src/collection"
+	"github.com/newrelic/nri-postgresql/src/collection/repository"
+	"github.com/newrelic/nri-postgresql/src/collector"
 	"github.com/newrelic/nri-postgresql/src/connection"
+	"golang.org/x/sync/errgroup"
 )
 
 const (
 	versionQuery = `SHOW server_version`
 )
 
-// PopulateMetrics collects metrics for each type
-func PopulateMetrics(ci connection.Info, databaseList collection.DatabaseList, instance *integration.Entity, i *integration.Integration, collectPgBouncer, collectDbLocks bool) {
+// entityMu guards pgIntegration.Entity lookups/creation, which is not safe for concurrent
+// use, against the worker pools in PopulateTableMetrics and PopulateIndexMetrics.
+var entityMu sync.Mutex
 
-	con, err := ci.NewConnection(ci.DatabaseName())
+// PopulateMetrics collects metrics for each type. ctx bounds the whole collection cycle
+// (--collection_timeout); each individual query is further bounded by the connection's
+// own --query_timeout so a single slow query can't stall the rest of the run.
+func PopulateMetrics(ctx context.Context, ci *connection.Info, databaseList collection.DatabaseList, instance *integration.Entity, i *integration.Integration, collectPgBouncer, collectDbLocks, collectQueryStats, obfuscateQueries bool, customMetricsConfig string) {
+
+	con, err := ci.NewConnection(ctx, ci.DatabaseName())
 	if err != nil {
 		log.Error("Metrics collection failed: error creating connection to SQL Server: %s", err.Error())
 		return
 	}
 	defer con.Close()
 
-	version, err := collectVersion(con)
+	version, err := collectVersion(ctx, con)
 	if err != nil {
 		log.Error("Metrics collection failed: error collecting version number: %s", err.Error())
 		return
 	}
 
-	PopulateInstanceMetrics(instance, version, con)
-	PopulateDatabaseMetrics(databaseList, version, i, con, ci)
+	results := []collector.Result{
+		PopulateInstanceMetrics(ctx, instance, version, con, ci),
+		PopulateDatabaseMetrics(ctx, databaseList, version, i, con, ci),
+	}
 	if collectDbLocks {
-		PopulateDatabaseLockMetrics(databaseList, version, i, con, ci)
+		results = append(results, PopulateDatabaseLockMetrics(ctx, databaseList, version, i, con, ci))
+	}
+	results = append(results,
+		PopulateTableMetrics(ctx, databaseList, i, ci),
+		PopulateIndexMetrics(ctx, databaseList, i, ci),
+		PopulateCustomMetrics(ctx, customMetricsConfig, databaseList, version, instance, i, con, ci),
+	)
+	if collectQueryStats {
+		results = append(results, PopulateQueryMetrics(ctx, i, con, ci, obfuscateQueries))
 	}
-	PopulateTableMetrics(databaseList, i, ci)
-	PopulateIndexMetrics(databaseList, i, ci)
 
 	if collectPgBouncer {
-		con, err = ci.NewConnection("pgbouncer")
+		con, err = ci.NewConnection(ctx, "pgbouncer")
 		if err != nil {
 			log.Error("Error creating connection to pgbouncer database: %s", err)
 		} else {
 			defer con.Close()
-			PopulatePgBouncerMetrics(i, con, ci)
+			results = append(results, PopulatePgBouncerMetrics(ctx, i, con, ci))
 		}
 	}
+
+	emitIntegrationSample(instance, results)
+}
+
+// emitIntegrationSample logs a summary of this collection cycle and emits a
+// PostgresqlIntegrationSample self-metric onto instance recording how many collectors ran, how
+// many failed, and each collector's duration.
+func emitIntegrationSample(instance *integration.Entity, results []collector.Result) {
+	metricSet := instance.NewMetricSet("PostgresqlIntegrationSample",
+		metric.Attribute{Key: "displayName", Value: instance.Metadata.Name},
+		metric.Attribute{Key: "entityName", Value: instance.Metadata.Namespace + ":" + instance.Metadata.Name},
+	)
+
+	var errorCount, rowsEmitted int
+	for _, result := range results {
+		if result.Err != nil {
+			errorCount++
+			log.Error("Collector '%s' failed after %s: %s", result.Name, result.Duration, result.Err.Error())
+		} else {
+			log.Debug("Collector '%s' collected %d rows in %s", result.Name, result.RowsEmitted, result.Duration)
+		}
+		rowsEmitted += result.RowsEmitted
+
+		durationMetric := result.Name + ".durationMs"
+		if err := metricSet.SetMetric(durationMetric, float64(result.Duration.Milliseconds()), metric.GAUGE); err != nil {
+			log.Error("Failed to set %s metric: %s", durationMetric, err.Error())
+		}
+	}
+
+	if err := metricSet.SetMetric("collectorsExecuted", len(results), metric.GAUGE); err != nil {
+		log.Error("Failed to set collectorsExecuted metric: %s", err.Error())
+	}
+	if err := metricSet.SetMetric("collectorErrors", errorCount, metric.GAUGE); err != nil {
+		log.Error("Failed to set collectorErrors metric: %s", err.Error())
+	}
+	if err := metricSet.SetMetric("rowsEmitted", rowsEmitted, metric.GAUGE); err != nil {
+		log.Error("Failed to set rowsEmitted metric: %s", err.Error())
+	}
 }
 
 type serverVersionRow struct {
 	Version string `db:"server_version"`
 }
 
-func collectVersion(connection *connection.PGSQLConnection) (*semver.Version, error) {
+func collectVersion(ctx context.Context, connection *connection.PGSQLConnection) (*semver.Version, error) {
 	var versionRows []*serverVersionRow
-	if err := connection.Query(&versionRows, versionQuery); err != nil {
+	if err := connection.QueryContext(ctx, &versionRows, versionQuery); err != nil {
 		return nil, err
 	}
 
@@ -89,125 +148,226 @@ func parseSpecialVersion(version string, specialIndex int) (*semver.Version, err
 }
 
 // PopulateInstanceMetrics populates the metrics for an instance
-func PopulateInstanceMetrics(instanceEntity *integration.Entity, version *semver.Version, connection *connection.PGSQLConnection) {
-	metricSet := instanceEntity.NewMetricSet("PostgresqlInstanceSample",
-		metric.Attribute{Key: "displayName", Value: instanceEntity.Metadata.Name},
-		metric.Attribute{Key: "entityName", Value: instanceEntity.Metadata.Namespace + ":" + instanceEntity.Metadata.Name},
-	)
+func PopulateInstanceMetrics(ctx context.Context, instanceEntity *integration.Entity, version *semver.Version, connection *connection.PGSQLConnection, ci *connection.Info) collector.Result {
+	return collector.Run("instance", func() (int, error) {
+		database := ci.DatabaseName()
+		if !ci.Breaker.Allow("instance", database) {
+			log.Debug("Skipping instance metrics: circuit breaker open")
+			return 0, nil
+		}
 
-	for _, queryDef := range generateInstanceDefinitions(version) {
-		dataModels := queryDef.GetDataModels()
-		if err := connection.Query(dataModels, queryDef.GetQuery()); err != nil {
-			log.Error("Could not execute instance query: %s", err.Error())
-			continue
+		metricSet := instanceEntity.NewMetricSet("PostgresqlInstanceSample",
+			metric.Attribute{Key: "displayName", Value: instanceEntity.Metadata.Name},
+			metric.Attribute{Key: "entityName", Value: instanceEntity.Metadata.Namespace + ":" + instanceEntity.Metadata.Name},
+		)
+
+		rowsEmitted := 0
+		var lastErr error
+		for _, queryDef := range generateInstanceDefinitions(version) {
+			dataModels := queryDef.GetDataModels()
+			if err := connection.QueryContext(ctx, dataModels, queryDef.GetQuery()); err != nil {
+				log.Error("Could not execute instance query: %s", err.Error())
+				lastErr = err
+				continue
+			}
+
+			vp := reflect.Indirect(reflect.ValueOf(dataModels))
+
+			// Nothing was returned
+			if vp.Len() == 0 {
+				log.Debug("No data returned from instance query '%s'", queryDef.GetQuery())
+				continue
+			}
+
+			vpInterface := vp.Index(0).Interface()
+			if err := metricSet.MarshalMetrics(vpInterface); err != nil {
+				log.Error("Could not parse metrics from instance query result: %s", err.Error())
+				continue
+			}
+			rowsEmitted++
 		}
 
-		vp := reflect.Indirect(reflect.ValueOf(dataModels))
+		ci.Breaker.Record("instance", database, lastErr)
+		return rowsEmitted, lastErr
+	})
+}
 
-		// Nothing was returned
-		if vp.Len() == 0 {
-			log.Debug("No data returned from instance query '%s'", queryDef.GetQuery())
-			continue
+// PopulateDatabaseMetrics populates the metrics for a database
+func PopulateDatabaseMetrics(ctx context.Context, databases collection.DatabaseList, version *semver.Version, pgIntegration *integration.Integration, connection *connection.PGSQLConnection, ci *connection.Info) collector.Result {
+	return collector.Run("database", func() (int, error) {
+		database := ci.DatabaseName()
+		if !ci.Breaker.Allow("database", database) {
+			log.Debug("Skipping database metrics: circuit breaker open")
+			return 0, nil
 		}
 
-		vpInterface := vp.Index(0).Interface()
-		err := metricSet.MarshalMetrics(vpInterface)
+		databaseDefinitions := generateDatabaseDefinitions(databases, version)
+
+		repo, err := repository.NewDatabaseRepository(ci.Driver, ci, connection)
 		if err != nil {
-			log.Error("Could not parse metrics from instance query result: %s", err.Error())
+			ci.Breaker.Record("database", database, err)
+			return 0, err
 		}
-	}
-}
+		defer func() {
+			if err := repo.Close(); err != nil {
+				log.Error("Failed to close database repository: %s", err.Error())
+			}
+		}()
 
-// PopulateDatabaseMetrics populates the metrics for a database
-func PopulateDatabaseMetrics(databases collection.DatabaseList, version *semver.Version, pgIntegration *integration.Integration, connection *connection.PGSQLConnection, ci connection.Info) {
-	databaseDefinitions := generateDatabaseDefinitions(databases, version)
-	processDatabaseDefinitions(databaseDefinitions, pgIntegration, connection, ci)
+		rows, err := processDatabaseDefinitions(ctx, databaseDefinitions, pgIntegration, repo, ci)
+		ci.Breaker.Record("database", database, err)
+		return rows, err
+	})
 }
 
 // PopulateDatabaseLockMetrics populates the lock metrics for a database
-func PopulateDatabaseLockMetrics(databases collection.DatabaseList, version *semver.Version, pgIntegration *integration.Integration, connection *connection.PGSQLConnection, ci connection.Info) {
-	if !connection.HaveExtensionInSchema("tablefunc", "public") {
-		log.Warn("Crosstab function not available; database lock metric gathering not possible.")
-		log.Warn("To enable database lock metrics, enable the 'tablefunc' extension on the public")
-		log.Warn("schema of your database. You can do so by:")
-		log.Warn("  1. Installing the postgresql contribs package for your OS; and")
-		log.Warn("  2. Run the query 'CREATE EXTENSION tablefunc;' against your database's public schema")
-		return
-	}
+func PopulateDatabaseLockMetrics(ctx context.Context, databases collection.DatabaseList, version *semver.Version, pgIntegration *integration.Integration, connection *connection.PGSQLConnection, ci *connection.Info) collector.Result {
+	return collector.Run("databaseLocks", func() (int, error) {
+		database := ci.DatabaseName()
+		if !ci.Breaker.Allow("databaseLocks", database) {
+			log.Debug("Skipping database lock metrics: circuit breaker open")
+			return 0, nil
+		}
+
+		if !connection.HaveExtensionInSchema("tablefunc", "public") {
+			log.Warn("Crosstab function not available; database lock metric gathering not possible.")
+			log.Warn("To enable database lock metrics, enable the 'tablefunc' extension on the public")
+			log.Warn("schema of your database. You can do so by:")
+			log.Warn("  1. Installing the postgresql contribs package for your OS; and")
+			log.Warn("  2. Run the query 'CREATE EXTENSION tablefunc;' against your database's public schema")
+			return 0, nil
+		}
 
-	lockDefinitions := generateLockDefinitions(databases, version)
+		lockDefinitions := generateLockDefinitions(databases, version)
+
+		repo, err := repository.NewDatabaseRepository(ci.Driver, ci, connection)
+		if err != nil {
+			ci.Breaker.Record("databaseLocks", database, err)
+			return 0, err
+		}
+		defer func() {
+			if err := repo.Close(); err != nil {
+				log.Error("Failed to close database repository: %s", err.Error())
+			}
+		}()
 
-	processDatabaseDefinitions(lockDefinitions, pgIntegration, connection, ci)
+		rows, err := processDatabaseDefinitions(ctx, lockDefinitions, pgIntegration, repo, ci)
+		ci.Breaker.Record("databaseLocks", database, err)
+		return rows, err
+	})
 }
 
-func processDatabaseDefinitions(definitions []*QueryDefinition, pgIntegration *integration.Integration, connection *connection.PGSQLConnection, ci connection.Info) {
+// processDatabaseDefinitions runs each definition's query through repo, a
+// repository.DatabaseRepository, and marshals the resulting typed rows onto the matching
+// pg-database entity via MarshalMetrics, exactly like populateTableMetricsForDatabase and
+// populateIndexMetricsForDatabase do for their own rows -- so each row's metric_name/
+// source_type tags are honored instead of the raw SQL column name forced onto metric.GAUGE.
+// Using the repository interface here (rather than *connection.PGSQLConnection directly) keeps
+// this function decoupled from the specific SQL driver selected by --driver. It returns the
+// total number of rows processed across every definition.
+func processDatabaseDefinitions(ctx context.Context, definitions []*QueryDefinition, pgIntegration *integration.Integration, repo repository.DatabaseRepository, ci *connection.Info) (int, error) {
+	rowsEmitted := 0
 	for _, queryDef := range definitions {
-		// collect into model
 		dataModels := queryDef.GetDataModels()
-		if err := connection.Query(dataModels, queryDef.GetQuery()); err != nil {
+		if err := repo.CollectDatabaseMetrics(ctx, dataModels, queryDef.GetQuery()); err != nil {
 			log.Error("Could not execute database query: %s", err.Error())
 			continue
 		}
 
-		// for each row in the response
 		v := reflect.Indirect(reflect.ValueOf(dataModels))
 		for i := 0; i < v.Len(); i++ {
-			db := v.Index(i).Interface()
-			name, err := GetDatabaseName(db)
+			row := v.Index(i).Interface()
+			dbName, err := GetDatabaseName(row)
 			if err != nil {
 				log.Error("Unable to get database name: %s", err.Error())
+				continue
 			}
 
 			host, port := ci.HostPort()
 			hostIDAttribute := integration.NewIDAttribute("host", host)
 			portIDAttribute := integration.NewIDAttribute("port", port)
-			databaseEntity, err := pgIntegration.Entity(name, "pg-database", hostIDAttribute, portIDAttribute)
+
+			entityMu.Lock()
+			databaseEntity, err := pgIntegration.Entity(dbName, "pg-database", hostIDAttribute, portIDAttribute)
+			entityMu.Unlock()
 			if err != nil {
-				log.Error("Failed to get database entity for name %s: %s", name, err.Error())
+				log.Error("Failed to get database entity for name %s: %s", dbName, err.Error())
+				continue
 			}
 			metricSet := databaseEntity.NewMetricSet("PostgresqlDatabaseSample",
 				metric.Attribute{Key: "displayName", Value: databaseEntity.Metadata.Name},
 				metric.Attribute{Key: "entityName", Value: "database:" + databaseEntity.Metadata.Name},
 			)
 
-			if err := metricSet.MarshalMetrics(db); err != nil {
-				log.Error("Failed to database entity with metrics: %s", err.Error())
+			if err := metricSet.MarshalMetrics(row); err != nil {
+				log.Error("Failed to populate database entity with metrics: %s", err.Error())
+				continue
 			}
-
+			rowsEmitted++
 		}
 	}
+	return rowsEmitted, nil
 }
 
-// PopulateTableMetrics populates the metrics for a table
-func PopulateTableMetrics(databases collection.DatabaseList, pgIntegration *integration.Integration, ci connection.Info) {
-	for database, schemaList := range databases {
-		if len(schemaList) == 0 {
-			return
+// PopulateTableMetrics populates the metrics for a table, fanning out across databases with
+// a worker pool bounded by --max_concurrent_collections.
+func PopulateTableMetrics(ctx context.Context, databases collection.DatabaseList, pgIntegration *integration.Integration, ci *connection.Info) collector.Result {
+	return collector.Run("table", func() (int, error) {
+		g, gCtx := errgroup.WithContext(ctx)
+		if ci.MaxConcurrentCollections > 0 {
+			g.SetLimit(ci.MaxConcurrentCollections)
 		}
 
-		// Create a new connection to the database
-		con, err := ci.NewConnection(database)
-		defer con.Close()
-		if err != nil {
-			log.Error("Failed to connect to database %s: %s", database, err.Error())
-			continue
+		var rowsEmitted int64
+
+		for database, schemaList := range databases {
+			if len(schemaList) == 0 {
+				continue
+			}
+
+			database, schemaList := database, schemaList
+			g.Go(func() error {
+				if !ci.Breaker.Allow("table", database) {
+					log.Debug("Skipping table metrics for database %s: circuit breaker open", database)
+					return nil
+				}
+
+				// Acquire a connection from the pool shared across this collection cycle
+				con, err := ci.NewConnection(gCtx, database)
+				if err != nil {
+					log.Error("Failed to connect to database %s: %s", database, err.Error())
+					ci.Breaker.Record("table", database, err)
+					return nil
+				}
+				defer con.Close()
+
+				rows, err := populateTableMetricsForDatabase(gCtx, schemaList, con, pgIntegration, ci)
+				ci.Breaker.Record("table", database, err)
+				if err != nil {
+					log.Error("Table metrics collection failed for database %s: %s", database, err.Error())
+				}
+				atomic.AddInt64(&rowsEmitted, int64(rows))
+				return nil
+			})
 		}
 
-		populateTableMetricsForDatabase(schemaList, con, pgIntegration, ci)
-	}
+		err := g.Wait()
+		return int(rowsEmitted), err
+	})
 }
 
-func populateTableMetricsForDatabase(schemaList collection.SchemaList, con *connection.PGSQLConnection, pgIntegration *integration.Integration, ci connection.Info) {
+func populateTableMetricsForDatabase(ctx context.Context, schemaList collection.SchemaList, con *connection.PGSQLConnection, pgIntegration *integration.Integration, ci *connection.Info) (int, error) {
 
 	tableDefinitions := generateTableDefinitions(schemaList)
 
+	rowsEmitted := 0
+
 	// collect into model
 	for _, definition := range tableDefinitions {
 
 		dataModels := definition.GetDataModels()
-		if err := con.Query(dataModels, definition.GetQuery()); err != nil {
-			log.Error("Could not execute table query: %s", err.Error())
-			return
+		if err := con.QueryContext(ctx, dataModels, definition.GetQuery()); err != nil {
+			return rowsEmitted, fmt.Errorf("could not execute table query: %w", err)
 		}
 
 		// for each row in the response
@@ -232,7 +392,10 @@ func populateTableMetricsForDatabase(schemaList collection.SchemaList, con *conn
 			portIDAttribute := integration.NewIDAttribute("port", port)
 			databaseIDAttribute := integration.NewIDAttribute("pg-database", dbName)
 			schemaIDAttribute := integration.NewIDAttribute("pg-schema", schemaName)
+
+			entityMu.Lock()
 			tableEntity, err := pgIntegration.Entity(tableName, "pg-table", hostIDAttribute, portIDAttribute, databaseIDAttribute, schemaIDAttribute)
+			entityMu.Unlock()
 			if err != nil {
 				log.Error("Failed to get table entity for table %s: %s", tableName, err.Error())
 			}
@@ -246,34 +409,66 @@ func populateTableMetricsForDatabase(schemaList collection.SchemaList, con *conn
 			if err := metricSet.MarshalMetrics(row); err != nil {
 				log.Error("Failed to populate table entity with metrics: %s", err.Error())
 			}
+			rowsEmitted++
 
 		}
 	}
+	return rowsEmitted, nil
 }
 
-// PopulateIndexMetrics populates the metrics for an index
-func PopulateIndexMetrics(databases collection.DatabaseList, pgIntegration *integration.Integration, ci connection.Info) {
-	for database, schemaList := range databases {
-		con, err := ci.NewConnection(database)
-		if err != nil {
-			log.Error("Failed to create new connection to database %s: %s", database, err.Error())
-			continue
+// PopulateIndexMetrics populates the metrics for an index, fanning out across databases with
+// a worker pool bounded by --max_concurrent_collections.
+func PopulateIndexMetrics(ctx context.Context, databases collection.DatabaseList, pgIntegration *integration.Integration, ci *connection.Info) collector.Result {
+	return collector.Run("index", func() (int, error) {
+		g, gCtx := errgroup.WithContext(ctx)
+		if ci.MaxConcurrentCollections > 0 {
+			g.SetLimit(ci.MaxConcurrentCollections)
 		}
-		defer con.Close()
-		populateIndexMetricsForDatabase(schemaList, con, pgIntegration, ci)
-	}
+
+		var rowsEmitted int64
+
+		for database, schemaList := range databases {
+			database, schemaList := database, schemaList
+			g.Go(func() error {
+				if !ci.Breaker.Allow("index", database) {
+					log.Debug("Skipping index metrics for database %s: circuit breaker open", database)
+					return nil
+				}
+
+				con, err := ci.NewConnection(gCtx, database)
+				if err != nil {
+					log.Error("Failed to create new connection to database %s: %s", database, err.Error())
+					ci.Breaker.Record("index", database, err)
+					return nil
+				}
+				defer con.Close()
+
+				rows, err := populateIndexMetricsForDatabase(gCtx, schemaList, con, pgIntegration, ci)
+				ci.Breaker.Record("index", database, err)
+				if err != nil {
+					log.Error("Index metrics collection failed for database %s: %s", database, err.Error())
+				}
+				atomic.AddInt64(&rowsEmitted, int64(rows))
+				return nil
+			})
+		}
+
+		err := g.Wait()
+		return int(rowsEmitted), err
+	})
 }
 
-func populateIndexMetricsForDatabase(schemaList collection.SchemaList, con *connection.PGSQLConnection, pgIntegration *integration.Integration, ci connection.Info) {
+func populateIndexMetricsForDatabase(ctx context.Context, schemaList collection.SchemaList, con *connection.PGSQLConnection, pgIntegration *integration.Integration, ci *connection.Info) (int, error) {
 	indexDefinitions := generateIndexDefinitions(schemaList)
 
+	rowsEmitted := 0
+
 	for _, definition := range indexDefinitions {
 
 		// collect into model
 		dataModels := definition.GetDataModels()
-		if err := con.Query(dataModels, definition.GetQuery()); err != nil {
-			log.Error("Could not execute index query: %s", err.Error())
-			return
+		if err := con.QueryContext(ctx, dataModels, definition.GetQuery()); err != nil {
+			return rowsEmitted, fmt.Errorf("could not execute index query: %w", err)
 		}
 
 		// for each row in the response
@@ -303,7 +498,10 @@ func populateIndexMetricsForDatabase(schemaList collection.SchemaList, con *conn
 			databaseIDAttribute := integration.NewIDAttribute("pg-database", dbName)
 			schemaIDAttribute := integration.NewIDAttribute("pg-schema", schemaName)
 			tableIDAttribute := integration.NewIDAttribute("pg-table", tableName)
+
+			entityMu.Lock()
 			indexEntity, err := pgIntegration.Entity(indexName, "pg-index", hostIDAttribute, portIDAttribute, databaseIDAttribute, schemaIDAttribute, tableIDAttribute)
+			entityMu.Unlock()
 			if err != nil {
 				log.Error("Failed to get table entity for index %s: %s", indexName, err.Error())
 			}
@@ -318,50 +516,65 @@ func populateIndexMetricsForDatabase(schemaList collection.SchemaList, con *conn
 			if err := metricSet.MarshalMetrics(row); err != nil {
 				log.Error("Failed to populate index entity with metrics: %s", err.Error())
 			}
+			rowsEmitted++
 
 		}
 
 	}
+	return rowsEmitted, nil
 }
 
 // PopulatePgBouncerMetrics populates pgbouncer metrics
-func PopulatePgBouncerMetrics(pgIntegration *integration.Integration, con *connection.PGSQLConnection, ci connection.Info) {
-	pgbouncerDefs := generatePgBouncerDefinitions()
-
-	for _, definition := range pgbouncerDefs {
-		dataModels := definition.GetDataModels()
-		if err := con.Query(dataModels, definition.GetQuery()); err != nil {
-			log.Error("Could not execute index query: %s", err.Error())
-			return
+func PopulatePgBouncerMetrics(ctx context.Context, pgIntegration *integration.Integration, con *connection.PGSQLConnection, ci *connection.Info) collector.Result {
+	return collector.Run("pgbouncer", func() (int, error) {
+		const pgbouncerDatabase = "pgbouncer"
+		if !ci.Breaker.Allow("pgbouncer", pgbouncerDatabase) {
+			log.Debug("Skipping pgbouncer metrics: circuit breaker open")
+			return 0, nil
 		}
 
-		// for each row in the response
-		v := reflect.Indirect(reflect.ValueOf(dataModels))
-		for i := 0; i < v.Len(); i++ {
-			db := v.Index(i).Interface()
-			name, err := GetDatabaseName(db)
-			if err != nil {
-				log.Error("Unable to get database name: %s", err.Error())
-				continue
-			}
+		pgbouncerDefs := generatePgBouncerDefinitions()
 
-			host, port := ci.HostPort()
-			hostIDAttribute := integration.NewIDAttribute("host", host)
-			portIDAttribute := integration.NewIDAttribute("port", port)
-			pgEntity, err := pgIntegration.Entity(name, "pgbouncer", hostIDAttribute, portIDAttribute)
-			if err != nil {
-				log.Error("Failed to get database entity for name %s: %s", name, err.Error())
+		rowsEmitted := 0
+		for _, definition := range pgbouncerDefs {
+			dataModels := definition.GetDataModels()
+			if err := con.QueryContext(ctx, dataModels, definition.GetQuery()); err != nil {
+				ci.Breaker.Record("pgbouncer", pgbouncerDatabase, err)
+				return rowsEmitted, fmt.Errorf("could not execute pgbouncer query: %w", err)
 			}
-			metricSet := pgEntity.NewMetricSet("PgBouncerSample",
-				metric.Attribute{Key: "displayName", Value: name},
-				metric.Attribute{Key: "entityName", Value: "pgbouncer:" + name},
-				metric.Attribute{Key: "host", Value: host},
-			)
 
-			if err := metricSet.MarshalMetrics(db); err != nil {
-				log.Error("Failed to populate pgbouncer entity with metrics: %s", err.Error())
-			}
+			// for each row in the response
+			v := reflect.Indirect(reflect.ValueOf(dataModels))
+			for i := 0; i < v.Len(); i++ {
+				db := v.Index(i).Interface()
+				name, err := GetDatabaseName(db)
+				if err != nil {
+					log.Error("Unable to get database name: %s", err.Error())
+					continue
+				}
+
+				host, port := ci.HostPort()
+				hostIDAttribute := integration.NewIDAttribute("host", host)
+				portIDAttribute := integration.NewIDAttribute("port", port)
+				pgEntity, err := pgIntegration.Entity(name, "pgbouncer", hostIDAttribute, portIDAttribute)
+				if err != nil {
+					log.Error("Failed to get database entity for name %s: %s", name, err.Error())
+				}
+				metricSet := pgEntity.NewMetricSet("PgBouncerSample",
+					metric.Attribute{Key: "displayName", Value: name},
+					metric.Attribute{Key: "entityName", Value: "pgbouncer:" + name},
+					metric.Attribute{Key: "host", Value: host},
+				)
+
+				if err := metricSet.MarshalMetrics(db); err != nil {
+					log.Error("Failed to populate pgbouncer entity with metrics: %s", err.Error())
+					continue
+				}
+				rowsEmitted++
 
+			}
 		}
-	}
+		ci.Breaker.Record("pgbouncer", pgbouncerDatabase, nil)
+		return rowsEmitted, nil
+	})
 }
@@ -0,0 +1,114 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/newrelic/infra-integrations-sdk/data/metric"
+	"github.com/newrelic/infra-integrations-sdk/integration"
+	"github.com/newrelic/infra-integrations-sdk/log"
+	"github.com/newrelic/nri-postgresql/src/collector"
+	"github.com/newrelic/nri-postgresql/src/connection"
+)
+
+const pgStatStatementsQuery = `
+SELECT
+	pss.queryid AS queryid,
+	pss.query AS query,
+	d.datname AS datname,
+	pss.calls AS calls,
+	pss.total_exec_time AS total_exec_time,
+	pss.mean_exec_time AS mean_exec_time,
+	pss.rows AS rows,
+	pss.shared_blks_hit AS shared_blks_hit,
+	pss.shared_blks_read AS shared_blks_read
+FROM pg_stat_statements pss
+JOIN pg_database d ON d.oid = pss.dbid
+`
+
+// literalPattern matches single-quoted string literals and bare integers, the two most
+// common places a query's bind values end up inlined into pg_stat_statements.query text.
+var literalPattern = regexp.MustCompile(`'[^']*'|\b\d+\b`)
+
+type queryStatRow struct {
+	QueryID        int64   `db:"queryid" metric_name:"query.id" source_type:"attribute"`
+	Query          string  `db:"query" metric_name:"query.text" source_type:"attribute"`
+	DatabaseName   string  `db:"datname" metric_name:"query.database" source_type:"attribute"`
+	Calls          int64   `db:"calls" metric_name:"query.calls" source_type:"rate"`
+	TotalExecTime  float64 `db:"total_exec_time" metric_name:"query.totalExecTimeMs" source_type:"gauge"`
+	MeanExecTime   float64 `db:"mean_exec_time" metric_name:"query.meanExecTimeMs" source_type:"gauge"`
+	Rows           int64   `db:"rows" metric_name:"query.rows" source_type:"rate"`
+	SharedBlksHit  int64   `db:"shared_blks_hit" metric_name:"query.sharedBlksHitPerSecond" source_type:"rate"`
+	SharedBlksRead int64   `db:"shared_blks_read" metric_name:"query.sharedBlksReadPerSecond" source_type:"rate"`
+}
+
+// PopulateQueryMetrics collects per-statement metrics from pg_stat_statements, gated by
+// --collect_query_stats. When obfuscateQueries is true, literals are stripped from the query
+// text before it's emitted so bind-parameter PII or secrets don't leak into New Relic.
+func PopulateQueryMetrics(ctx context.Context, pgIntegration *integration.Integration, con *connection.PGSQLConnection, ci *connection.Info, obfuscateQueries bool) collector.Result {
+	return collector.Run("queryStats", func() (int, error) {
+		database := ci.DatabaseName()
+		if !ci.Breaker.Allow("queryStats", database) {
+			log.Debug("Skipping query stats: circuit breaker open")
+			return 0, nil
+		}
+
+		if !con.HaveExtensionInSchema("pg_stat_statements", "public") {
+			log.Warn("pg_stat_statements extension not available; query-level metric gathering not possible.")
+			log.Warn("To enable query metrics, enable the 'pg_stat_statements' extension on the public")
+			log.Warn("schema of your database. You can do so by:")
+			log.Warn("  1. Adding 'pg_stat_statements' to shared_preload_libraries and restarting Postgresql; and")
+			log.Warn("  2. Run the query 'CREATE EXTENSION pg_stat_statements;' against your database's public schema")
+			return 0, nil
+		}
+
+		var rows []*queryStatRow
+		if err := con.QueryContext(ctx, &rows, pgStatStatementsQuery); err != nil {
+			ci.Breaker.Record("queryStats", database, err)
+			return 0, fmt.Errorf("could not execute pg_stat_statements query: %w", err)
+		}
+
+		host, port := ci.HostPort()
+		hostIDAttribute := integration.NewIDAttribute("host", host)
+		portIDAttribute := integration.NewIDAttribute("port", port)
+
+		rowsEmitted := 0
+		for _, row := range rows {
+			if obfuscateQueries {
+				row.Query = obfuscateQuery(row.Query)
+			}
+
+			queryIDName := strconv.FormatInt(row.QueryID, 10)
+			databaseIDAttribute := integration.NewIDAttribute("pg-database", row.DatabaseName)
+
+			entityMu.Lock()
+			queryEntity, err := pgIntegration.Entity(queryIDName, "pg-query", hostIDAttribute, portIDAttribute, databaseIDAttribute)
+			entityMu.Unlock()
+			if err != nil {
+				log.Error("Failed to get query entity for queryid %s: %s", queryIDName, err.Error())
+				continue
+			}
+
+			metricSet := queryEntity.NewMetricSet("PostgresqlQuerySample",
+				metric.Attribute{Key: "displayName", Value: queryEntity.Metadata.Name},
+				metric.Attribute{Key: "entityName", Value: "query:" + queryEntity.Metadata.Name},
+				metric.Attribute{Key: "database", Value: row.DatabaseName},
+			)
+
+			if err := metricSet.MarshalMetrics(row); err != nil {
+				log.Error("Failed to populate query entity with metrics: %s", err.Error())
+				continue
+			}
+			rowsEmitted++
+		}
+		ci.Breaker.Record("queryStats", database, nil)
+		return rowsEmitted, nil
+	})
+}
+
+// obfuscateQuery replaces string and numeric literals in query with a placeholder.
+func obfuscateQuery(query string) string {
+	return literalPattern.ReplaceAllString(query, "?")
+}
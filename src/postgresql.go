@@ -1,11 +1,16 @@
 package main
 
 import (
+	"context"
 	"os"
+	"time"
 
 	"github.com/newrelic/infra-integrations-sdk/integration"
 	"github.com/newrelic/infra-integrations-sdk/log"
 	"github.com/newrelic/nri-postgresql/src/args"
+	"github.com/newrelic/nri-postgresql/src/collection"
+	"github.com/newrelic/nri-postgresql/src/connection"
+	"github.com/newrelic/nri-postgresql/src/metrics"
 )
 
 const (
@@ -31,14 +36,34 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Create a new connection
-	// con, err := connection.NewConnection(&args)
-	// if err != nil {
-	// 	log.Error("Error creating connection to SQL Server: %s", err.Error())
-	// 	os.Exit(1)
-	// }
+	// ci is shared across the whole collection cycle so every Populate* call below draws
+	// from the same cached pgxpool.Pool(s) instead of opening a fresh TCP session per database.
+	ci := connection.DefaultConnectionInfo(&args)
+	defer ci.ClosePools()
+
+	collectionTimeout, err := time.ParseDuration(args.CollectionTimeout + "s")
+	if err != nil {
+		log.Warn("Failed to parse collection_timeout, defaulting to 55s: %s", err.Error())
+		collectionTimeout = 55 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), collectionTimeout)
+	defer cancel()
+
+	databaseList, err := collection.ParseCollectionList(args.CollectionList)
+	if err != nil {
+		log.Error("Error parsing collection_list: %s", err.Error())
+		os.Exit(1)
+	}
+
+	metrics.PopulateMetrics(ctx, ci, databaseList, i.LocalEntity(), i, args.PgBouncer, args.CollectDbLockMetrics, args.CollectQueryStats, args.ObfuscateQueries, args.CustomMetricsConfig)
+
+	// This integration runs as a short-lived process per collection cycle, so the circuit
+	// breaker's state only protects future runs if it's written back out here.
+	if err := ci.Breaker.Save(); err != nil {
+		log.Warn("Failed to persist collector circuit breaker state: %s", err.Error())
+	}
 
 	if err = i.Publish(); err != nil {
 		log.Error(err.Error())
 	}
-}
\ No newline at end of file
+}